@@ -0,0 +1,70 @@
+// Package export serializes journal entries in systemd's Journal Export
+// Format, as documented at
+// https://www.freedesktop.org/software/systemd/man/journal-export-format.html
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"unicode/utf8"
+
+	"github.com/xytis/j2g/journal"
+)
+
+// WriteEntry writes entry to w in Journal Export Format: one KEY=value
+// line per printable UTF-8 field, and for binary or multiline values the
+// form KEY\n<uint64 little-endian length>\n<raw bytes>\n. The record is
+// terminated by a blank line.
+func WriteEntry(w io.Writer, entry map[string]string) error {
+	for key, value := range entry {
+		if isPrintable(value) {
+			if _, err := io.WriteString(w, key+"="+value+"\n"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := io.WriteString(w, key+"\n"); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint64(len(value))); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, value); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJournalEntry reads the current entry from j and writes it to w in
+// Journal Export Format.
+func WriteJournalEntry(w io.Writer, j *journal.Journal) error {
+	entry, err := j.GetEntry()
+	if err != nil {
+		return err
+	}
+	return WriteEntry(w, entry)
+}
+
+// isPrintable reports whether value can be represented on a single
+// KEY=value line: valid UTF-8, with no byte below 0x20 other than tab.
+// Anything else forces the binary form.
+func isPrintable(value string) bool {
+	for i, r := range value {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(value[i:]); size == 1 {
+				return false
+			}
+		}
+		if r < 0x20 && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package export
+
+import "testing"
+
+func TestIsPrintable(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "plain ascii", value: "hello world", want: true},
+		{name: "utf8", value: "héllo wörld", want: true},
+		{name: "tab allowed", value: "a\tb", want: true},
+		{name: "newline forces binary", value: "a\nb", want: false},
+		{name: "null byte forces binary", value: "a\x00b", want: false},
+		{name: "invalid utf8 forces binary", value: "a\xffb", want: false},
+		{name: "empty string", value: "", want: true},
+	}
+
+	for _, c := range cases {
+		if got := isPrintable(c.value); got != c.want {
+			t.Errorf("%s: isPrintable(%q) = %v, want %v", c.name, c.value, got, c.want)
+		}
+	}
+}
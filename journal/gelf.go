@@ -0,0 +1,84 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gelfReservedFields are field names GELF reserves for its own use; they
+// must never be emitted as additional fields.
+var gelfReservedFields = map[string]bool{
+	"_id": true,
+}
+
+// ToGELF converts a raw journal entry, as returned by Journal.GetEntry, into
+// a GELF 1.1 compliant JSON document suitable for sending to a Graylog
+// input.
+//
+// _HOSTNAME becomes host, MESSAGE becomes short_message (its first line) and
+// full_message (the whole body), PRIORITY is parsed as the numeric level,
+// and __REALTIME_TIMESTAMP (microseconds) becomes a float timestamp in
+// seconds. sd_journal_enumerate_data never returns __REALTIME_TIMESTAMP, so
+// callers using Journal.GetEntry must set it themselves from
+// Journal.GetRealtimeUsec before calling ToGELF. Every other field is kept
+// as a GELF additional field, lowercased and re-prefixed with a single
+// underscore, so systemd trusted fields such as _SYSTEMD_UNIT become
+// _systemd_unit.
+//
+// ToGELF returns a nil document without error for CONTAINER_PARTIAL_MESSAGE
+// fragments; callers must reassemble those first (see PartialBuffer) and
+// only pass complete entries here.
+func ToGELF(entry map[string]string) ([]byte, error) {
+	if entry["CONTAINER_PARTIAL_MESSAGE"] == "true" {
+		return nil, nil
+	}
+
+	doc := make(map[string]interface{}, len(entry)+4)
+	doc["version"] = "1.1"
+
+	if host, ok := entry["_HOSTNAME"]; ok {
+		doc["host"] = host
+	}
+
+	if message, ok := entry["MESSAGE"]; ok {
+		doc["full_message"] = message
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			doc["short_message"] = message[:i]
+		} else {
+			doc["short_message"] = message
+		}
+	}
+
+	if priority, ok := entry["PRIORITY"]; ok {
+		level, err := strconv.Atoi(priority)
+		if err != nil || level < 0 || level > 7 {
+			return nil, fmt.Errorf("invalid PRIORITY %q", priority)
+		}
+		doc["level"] = level
+	}
+
+	if realtime, ok := entry["__REALTIME_TIMESTAMP"]; ok {
+		usec, err := strconv.ParseFloat(realtime, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid __REALTIME_TIMESTAMP %q: %v", realtime, err)
+		}
+		doc["timestamp"] = usec / 1e6
+	}
+
+	for field, value := range entry {
+		switch field {
+		case "_HOSTNAME", "MESSAGE", "PRIORITY", "__REALTIME_TIMESTAMP":
+			continue
+		}
+
+		name := "_" + strings.ToLower(strings.TrimLeft(field, "_"))
+		if gelfReservedFields[name] {
+			continue
+		}
+		doc[name] = value
+	}
+
+	return json.Marshal(doc)
+}
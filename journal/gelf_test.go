@@ -0,0 +1,81 @@
+package journal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToGELF(t *testing.T) {
+	cases := []struct {
+		name    string
+		entry   map[string]string
+		want    map[string]interface{}
+		wantNil bool
+		wantErr bool
+	}{
+		{
+			name: "basic fields",
+			entry: map[string]string{
+				"_HOSTNAME":            "host1",
+				"MESSAGE":              "line one\nline two",
+				"PRIORITY":             "6",
+				"__REALTIME_TIMESTAMP": "1000000",
+				"_SYSTEMD_UNIT":        "foo.service",
+			},
+			want: map[string]interface{}{
+				"version":       "1.1",
+				"host":          "host1",
+				"short_message": "line one",
+				"full_message":  "line one\nline two",
+				"level":         float64(6),
+				"timestamp":     1.0,
+				"_systemd_unit": "foo.service",
+			},
+		},
+		{
+			name:    "partial message fragment is skipped",
+			entry:   map[string]string{"CONTAINER_PARTIAL_MESSAGE": "true"},
+			wantNil: true,
+		},
+		{
+			name:    "invalid priority",
+			entry:   map[string]string{"PRIORITY": "nope"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timestamp",
+			entry:   map[string]string{"__REALTIME_TIMESTAMP": "nope"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		raw, err := ToGELF(c.entry)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got none", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if c.wantNil {
+			if raw != nil {
+				t.Errorf("%s: expected nil document, got %s", c.name, raw)
+			}
+			continue
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(raw, &got); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", c.name, err)
+		}
+		for k, v := range c.want {
+			if got[k] != v {
+				t.Errorf("%s: field %q = %v, want %v", c.name, k, got[k], v)
+			}
+		}
+	}
+}
@@ -34,12 +34,22 @@ const (
 type Journal struct {
 	cjournal *C.sd_journal
 	mu       sync.Mutex
+
+	// notifyWG is done once any Notify goroutine has fully exited, so
+	// Close can wait for it before freeing cjournal.
+	notifyWG sync.WaitGroup
 }
 
-// NewJournal returns a new Journal instance pointing to the local journal
+// NewJournal returns a new Journal instance pointing to the local journal.
 func NewJournal() (*Journal, error) {
+	return Open(0)
+}
+
+// Open returns a new Journal instance pointing to the local journal,
+// combining the given SD_JOURNAL_* flags with SD_JOURNAL_LOCAL_ONLY.
+func Open(flags int) (*Journal, error) {
 	j := &Journal{}
-	r := C.sd_journal_open(&j.cjournal, C.SD_JOURNAL_LOCAL_ONLY)
+	r := C.sd_journal_open(&j.cjournal, C.int(flags)|C.SD_JOURNAL_LOCAL_ONLY)
 
 	if r < 0 {
 		return nil, fmt.Errorf("failed to open journal: %d", r)
@@ -48,8 +58,12 @@ func NewJournal() (*Journal, error) {
 	return j, nil
 }
 
-// Close closes a journal opened with NewJournal.
+// Close closes a journal opened with NewJournal. It waits for any
+// in-flight Notify goroutine to exit first, so it never frees the
+// underlying sd_journal while that goroutine might still be using it.
 func (j *Journal) Close() error {
+	j.notifyWG.Wait()
+
 	j.mu.Lock()
 	C.sd_journal_close(j.cjournal)
 	j.mu.Unlock()
@@ -202,6 +216,60 @@ func (j *Journal) SeekRealtimeUsec(usec uint64) error {
 	return nil
 }
 
+// SeekCursor seeks to the entry identified by the given cursor string, as
+// previously obtained from GetCursor.
+func (j *Journal) SeekCursor(cursor string) error {
+	c := C.CString(cursor)
+	defer C.free(unsafe.Pointer(c))
+
+	j.mu.Lock()
+	r := C.sd_journal_seek_cursor(j.cjournal, c)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return fmt.Errorf("failed to seek to cursor %s: %d", cursor, r)
+	}
+
+	return nil
+}
+
+// GetCursor returns an opaque cursor string identifying the journal's
+// current read position, suitable for later use with SeekCursor to resume
+// forwarding without losing or duplicating entries.
+func (j *Journal) GetCursor() (string, error) {
+	var c *C.char
+
+	j.mu.Lock()
+	r := C.sd_journal_get_cursor(j.cjournal, &c)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return "", fmt.Errorf("failed to get cursor: %d", r)
+	}
+	defer C.free(unsafe.Pointer(c))
+
+	return C.GoString(c), nil
+}
+
+// TestCursor reports whether the journal's current entry matches the given
+// cursor. It is typically called right after SeekCursor to detect that the
+// journal has since rotated the entry out, in which case the seek landed
+// on the nearest remaining entry instead.
+func (j *Journal) TestCursor(cursor string) (bool, error) {
+	c := C.CString(cursor)
+	defer C.free(unsafe.Pointer(c))
+
+	j.mu.Lock()
+	r := C.sd_journal_test_cursor(j.cjournal, c)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return false, fmt.Errorf("failed to test cursor %s: %d", cursor, r)
+	}
+
+	return r > 0, nil
+}
+
 // Wait will synchronously wait until the journal gets changed. The maximum time
 // this call sleeps may be controlled with the timeout parameter.  If
 // IndefiniteWait is passed as the timeout parameter, Wait will
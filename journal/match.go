@@ -0,0 +1,103 @@
+package journal
+
+/*
+#cgo pkg-config: libsystemd
+#include <systemd/sd-journal.h>
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// AddMatch adds a match to the journal, restricting subsequent calls to
+// Next to entries where field equals value. Matches on different fields
+// added one after another without an intervening AddDisjunction are ANDed
+// together; matches on the same field are ORed.
+func (j *Journal) AddMatch(field, value string) error {
+	match := field + "=" + value
+	m := C.CString(match)
+	defer C.free(unsafe.Pointer(m))
+
+	j.mu.Lock()
+	r := C.sd_journal_add_match(j.cjournal, unsafe.Pointer(m), C.size_t(len(match)))
+	j.mu.Unlock()
+
+	if r < 0 {
+		return fmt.Errorf("failed to add match %s: %d", match, r)
+	}
+	return nil
+}
+
+// AddDisjunction inserts a logical OR between the matches added with
+// AddMatch before and after it.
+func (j *Journal) AddDisjunction() error {
+	j.mu.Lock()
+	r := C.sd_journal_add_disjunction(j.cjournal)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return fmt.Errorf("failed to add match disjunction: %d", r)
+	}
+	return nil
+}
+
+// AddConjunction inserts a logical AND between the matches added with
+// AddMatch before and after it.
+func (j *Journal) AddConjunction() error {
+	j.mu.Lock()
+	r := C.sd_journal_add_conjunction(j.cjournal)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return fmt.Errorf("failed to add match conjunction: %d", r)
+	}
+	return nil
+}
+
+// FlushMatches removes all matches, disjunctions and conjunctions added so
+// far, so that the journal again returns every entry.
+func (j *Journal) FlushMatches() {
+	j.mu.Lock()
+	C.sd_journal_flush_matches(j.cjournal)
+	j.mu.Unlock()
+}
+
+// FilterUnits restricts the journal to entries logged by any of the given
+// systemd units. Matches on the same field are automatically ORed together
+// by sd_journal, so no explicit AddDisjunction is needed between them.
+func (j *Journal) FilterUnits(units ...string) error {
+	for _, unit := range units {
+		if err := j.AddMatch("_SYSTEMD_UNIT", unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterPriority restricts the journal to entries with a PRIORITY between
+// min and max, inclusive. Matches on the same field are automatically ORed
+// together by sd_journal, so no explicit AddDisjunction is needed between
+// them.
+func (j *Journal) FilterPriority(min, max int) error {
+	for p := min; p <= max; p++ {
+		if err := j.AddMatch("PRIORITY", strconv.Itoa(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterBootID restricts the journal to entries logged during the boot
+// identified by id.
+func (j *Journal) FilterBootID(id string) error {
+	return j.AddMatch("_BOOT_ID", id)
+}
+
+// FilterSince seeks the journal to the first entry logged at or after t.
+func (j *Journal) FilterSince(t time.Time) error {
+	return j.SeekRealtimeUsec(uint64(t.UnixNano() / 1000))
+}
@@ -0,0 +1,185 @@
+package journal
+
+/*
+#cgo pkg-config: libsystemd
+#include <systemd/sd-journal.h>
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Fd returns a file descriptor that may be used with poll(2) or an epoll
+// loop to wait for journal changes; it becomes readable when Process
+// should be called. See sd_journal_get_fd(3).
+func (j *Journal) Fd() (int, error) {
+	j.mu.Lock()
+	r := C.sd_journal_get_fd(j.cjournal)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return -1, fmt.Errorf("failed to get journal fd: %d", r)
+	}
+	return int(r), nil
+}
+
+// Events returns the poll(2) events mask to watch Fd() for.
+func (j *Journal) Events() (int, error) {
+	j.mu.Lock()
+	r := C.sd_journal_get_events(j.cjournal)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return 0, fmt.Errorf("failed to get journal events: %d", r)
+	}
+	return int(r), nil
+}
+
+// Timeout returns the maximum duration to wait for Fd() to become ready
+// before calling Process anyway, as sd_journal_get_timeout dictates.
+// IndefiniteWait is returned if there is no timeout.
+func (j *Journal) Timeout() (time.Duration, error) {
+	var usec C.uint64_t
+
+	j.mu.Lock()
+	r := C.sd_journal_get_timeout(j.cjournal, &usec)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return 0, fmt.Errorf("failed to get journal timeout: %d", r)
+	}
+	if uint64(usec) == ^uint64(0) {
+		return IndefiniteWait, nil
+	}
+	return time.Duration(usec) * time.Microsecond, nil
+}
+
+// Process processes events that occurred on Fd(), returning one of the
+// SD_JOURNAL_* event constants.
+func (j *Journal) Process() (int, error) {
+	j.mu.Lock()
+	r := C.sd_journal_process(j.cjournal)
+	j.mu.Unlock()
+
+	if r < 0 {
+		return 0, fmt.Errorf("failed to process journal events: %d", r)
+	}
+	return int(r), nil
+}
+
+// Notify registers the journal's Fd() with an epoll loop running in its own
+// goroutine and delivers SD_JOURNAL_APPEND/INVALIDATE events on the
+// returned channel, without holding j's mutex while waiting, unlike Wait.
+//
+// A self-pipe is added to the same epoll set so that canceling ctx wakes
+// the loop immediately, even while waiting indefinitely. The returned
+// channel is closed once the goroutine exits, whether because ctx was
+// canceled or a journal call failed; Close waits for that exit before
+// freeing the journal, so it is safe to call Close concurrently with a
+// pending Notify.
+func (j *Journal) Notify(ctx context.Context) <-chan int {
+	events := make(chan int)
+
+	j.notifyWG.Add(1)
+	go func() {
+		defer j.notifyWG.Done()
+		defer close(events)
+
+		fd, err := j.Fd()
+		if err != nil {
+			return
+		}
+
+		mask, err := j.Events()
+		if err != nil {
+			return
+		}
+
+		epfd, err := unix.EpollCreate1(0)
+		if err != nil {
+			return
+		}
+		defer unix.Close(epfd)
+
+		if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, fd, &unix.EpollEvent{Events: uint32(mask), Fd: int32(fd)}); err != nil {
+			return
+		}
+
+		wakeR, wakeW, err := os.Pipe()
+		if err != nil {
+			return
+		}
+		defer wakeR.Close()
+
+		wakeFd := int(wakeR.Fd())
+		if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, wakeFd, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(wakeFd)}); err != nil {
+			wakeW.Close()
+			return
+		}
+		go func() {
+			<-ctx.Done()
+			wakeW.Close()
+		}()
+
+		epollEvents := make([]unix.EpollEvent, 2)
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			timeout, err := j.Timeout()
+			if err != nil {
+				return
+			}
+
+			ms := -1
+			if timeout != IndefiniteWait {
+				ms = int(timeout / time.Millisecond)
+			}
+
+			n, err := unix.EpollWait(epfd, epollEvents, ms)
+			if err != nil {
+				if err == unix.EINTR {
+					continue
+				}
+				return
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if n == 0 {
+				continue
+			}
+
+			woken := false
+			for _, ev := range epollEvents[:n] {
+				if int(ev.Fd) == wakeFd {
+					woken = true
+				}
+			}
+			if woken {
+				return
+			}
+
+			e, err := j.Process()
+			if err != nil {
+				return
+			}
+
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
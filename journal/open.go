@@ -0,0 +1,82 @@
+package journal
+
+/*
+#cgo pkg-config: libsystemd
+#include <systemd/sd-journal.h>
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Journal open flags, passed to OpenDirectory, OpenFiles, and
+// OpenNamespace. They mirror the SD_JOURNAL_* flags accepted by
+// sd_journal_open(3) and friends.
+const (
+	SD_JOURNAL_LOCAL_ONLY     = int(C.SD_JOURNAL_LOCAL_ONLY)
+	SD_JOURNAL_RUNTIME_ONLY   = int(C.SD_JOURNAL_RUNTIME_ONLY)
+	SD_JOURNAL_SYSTEM         = int(C.SD_JOURNAL_SYSTEM)
+	SD_JOURNAL_CURRENT_USER   = int(C.SD_JOURNAL_CURRENT_USER)
+	SD_JOURNAL_OS_ROOT        = int(C.SD_JOURNAL_OS_ROOT)
+	SD_JOURNAL_ALL_NAMESPACES = int(C.SD_JOURNAL_ALL_NAMESPACES)
+)
+
+// OpenDirectory returns a new Journal reading the journal files found in
+// path, e.g. a mounted /var/log/journal of another host, or a directory
+// produced by systemd-journal-remote.
+func OpenDirectory(path string, flags int) (*Journal, error) {
+	p := C.CString(path)
+	defer C.free(unsafe.Pointer(p))
+
+	j := &Journal{}
+	r := C.sd_journal_open_directory(&j.cjournal, p, C.int(flags))
+
+	if r < 0 {
+		return nil, fmt.Errorf("failed to open journal directory %s: %d", path, r)
+	}
+
+	return j, nil
+}
+
+// OpenFiles returns a new Journal reading the specific journal files named
+// in paths.
+func OpenFiles(paths []string, flags int) (*Journal, error) {
+	cpaths := make([]*C.char, len(paths)+1)
+	for i, p := range paths {
+		cpaths[i] = C.CString(p)
+	}
+	defer func() {
+		for _, p := range cpaths {
+			if p != nil {
+				C.free(unsafe.Pointer(p))
+			}
+		}
+	}()
+
+	j := &Journal{}
+	r := C.sd_journal_open_files(&j.cjournal, &cpaths[0], C.int(flags))
+
+	if r < 0 {
+		return nil, fmt.Errorf("failed to open journal files %v: %d", paths, r)
+	}
+
+	return j, nil
+}
+
+// OpenNamespace returns a new Journal reading the journal namespace named
+// name.
+func OpenNamespace(name string, flags int) (*Journal, error) {
+	n := C.CString(name)
+	defer C.free(unsafe.Pointer(n))
+
+	j := &Journal{}
+	r := C.sd_journal_open_namespace(&j.cjournal, n, C.int(flags))
+
+	if r < 0 {
+		return nil, fmt.Errorf("failed to open journal namespace %s: %d", name, r)
+	}
+
+	return j, nil
+}
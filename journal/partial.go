@@ -0,0 +1,110 @@
+package journal
+
+// PartialBuffer reassembles Docker-style CONTAINER_PARTIAL_MESSAGE
+// fragments, keyed by CONTAINER_ID_FULL, into complete journal entries.
+//
+// It is bounded by maxEntries: once that many containers have a fragment
+// in flight, adding one more evicts the least recently touched container,
+// so a container that never sends a terminating (non-partial) entry
+// cannot leak memory indefinitely. It is further bounded by
+// maxMessageBytes: a container whose accumulated MESSAGE grows past that
+// size has its fragment dropped outright, so a single never-terminating
+// stream cannot grow without bound between evictions either.
+type PartialBuffer struct {
+	maxEntries      int
+	maxMessageBytes int
+	order           []string // CONTAINER_ID_FULL, most-recently-touched last
+	pending         map[string]*partialEntry
+}
+
+type partialEntry struct {
+	message string
+	entry   map[string]string
+}
+
+// NewPartialBuffer returns a PartialBuffer that holds fragments for at most
+// maxEntries containers at once, each accumulating at most maxMessageBytes
+// of MESSAGE before being dropped. maxMessageBytes <= 0 means unbounded.
+func NewPartialBuffer(maxEntries, maxMessageBytes int) *PartialBuffer {
+	return &PartialBuffer{
+		maxEntries:      maxEntries,
+		maxMessageBytes: maxMessageBytes,
+		pending:         make(map[string]*partialEntry),
+	}
+}
+
+// Add feeds a raw journal entry into the buffer. If entry is not part of a
+// partial message, it is returned unchanged. Otherwise its MESSAGE is
+// appended to the in-flight fragment for CONTAINER_ID_FULL, and Add returns
+// (nil, false) until a non-partial fragment arrives, at which point it
+// returns the reassembled entry, carrying the metadata of that final
+// fragment.
+//
+// If the accumulated MESSAGE exceeds maxMessageBytes, the fragment is
+// dropped and Add returns (nil, false), the same as for an incomplete
+// fragment; the container has to start a fresh partial message to be
+// reassembled again.
+func (b *PartialBuffer) Add(entry map[string]string) (map[string]string, bool) {
+	id := entry["CONTAINER_ID_FULL"]
+	if id == "" {
+		return entry, true
+	}
+
+	p, ok := b.pending[id]
+	if !ok {
+		if entry["CONTAINER_PARTIAL_MESSAGE"] != "true" {
+			return entry, true
+		}
+		b.evict()
+		p = &partialEntry{}
+		b.pending[id] = p
+		b.order = append(b.order, id)
+	} else {
+		b.touch(id)
+	}
+	p.message += entry["MESSAGE"]
+	p.entry = entry
+
+	if b.maxMessageBytes > 0 && len(p.message) > b.maxMessageBytes {
+		delete(b.pending, id)
+		b.remove(id)
+		return nil, false
+	}
+
+	if entry["CONTAINER_PARTIAL_MESSAGE"] == "true" {
+		return nil, false
+	}
+
+	delete(b.pending, id)
+	b.remove(id)
+
+	full := p.entry
+	full["MESSAGE"] = p.message
+	return full, true
+}
+
+// touch moves id to the most-recently-touched end of order.
+func (b *PartialBuffer) touch(id string) {
+	b.remove(id)
+	b.order = append(b.order, id)
+}
+
+// remove drops id from order, if present.
+func (b *PartialBuffer) remove(id string) {
+	for i, v := range b.order {
+		if v == id {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// evict drops the least recently touched container once the buffer is full.
+func (b *PartialBuffer) evict() {
+	if b.maxEntries <= 0 || len(b.order) < b.maxEntries {
+		return
+	}
+	oldest := b.order[0]
+	b.order = b.order[1:]
+	delete(b.pending, oldest)
+}
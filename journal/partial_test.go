@@ -0,0 +1,75 @@
+package journal
+
+import "testing"
+
+func TestPartialBufferReassembles(t *testing.T) {
+	b := NewPartialBuffer(8, 0)
+
+	if _, ready := b.Add(map[string]string{
+		"CONTAINER_ID_FULL":         "abc",
+		"CONTAINER_PARTIAL_MESSAGE": "true",
+		"MESSAGE":                   "hello ",
+	}); ready {
+		t.Fatalf("expected partial fragment to not be ready")
+	}
+
+	full, ready := b.Add(map[string]string{
+		"CONTAINER_ID_FULL": "abc",
+		"MESSAGE":           "world",
+	})
+	if !ready {
+		t.Fatalf("expected final fragment to be ready")
+	}
+	if full["MESSAGE"] != "hello world" {
+		t.Errorf("MESSAGE = %q, want %q", full["MESSAGE"], "hello world")
+	}
+}
+
+func TestPartialBufferEvictsOldest(t *testing.T) {
+	b := NewPartialBuffer(1, 0)
+
+	b.Add(map[string]string{
+		"CONTAINER_ID_FULL":         "first",
+		"CONTAINER_PARTIAL_MESSAGE": "true",
+		"MESSAGE":                   "a",
+	})
+	b.Add(map[string]string{
+		"CONTAINER_ID_FULL":         "second",
+		"CONTAINER_PARTIAL_MESSAGE": "true",
+		"MESSAGE":                   "b",
+	})
+
+	full, ready := b.Add(map[string]string{
+		"CONTAINER_ID_FULL": "first",
+		"MESSAGE":           "z",
+	})
+	if !ready {
+		t.Fatalf("expected evicted container to start a fresh fragment")
+	}
+	if full["MESSAGE"] != "z" {
+		t.Errorf("MESSAGE = %q, want %q (evicted fragment should not survive)", full["MESSAGE"], "z")
+	}
+}
+
+func TestPartialBufferDropsOversizedMessage(t *testing.T) {
+	b := NewPartialBuffer(8, 5)
+
+	if _, ready := b.Add(map[string]string{
+		"CONTAINER_ID_FULL":         "abc",
+		"CONTAINER_PARTIAL_MESSAGE": "true",
+		"MESSAGE":                   "123456",
+	}); ready {
+		t.Fatalf("expected oversized fragment to not be ready")
+	}
+
+	full, ready := b.Add(map[string]string{
+		"CONTAINER_ID_FULL": "abc",
+		"MESSAGE":           "more",
+	})
+	if !ready {
+		t.Fatalf("expected dropped container to start a fresh fragment")
+	}
+	if full["MESSAGE"] != "more" {
+		t.Errorf("MESSAGE = %q, want %q (dropped fragment should not survive)", full["MESSAGE"], "more")
+	}
+}
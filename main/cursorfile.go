@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CursorFile persists a journal cursor to disk, so forwarding can resume
+// after a restart without losing or duplicating entries.
+type CursorFile struct {
+	path string
+}
+
+// NewCursorFile returns a CursorFile backed by path.
+func NewCursorFile(path string) *CursorFile {
+	return &CursorFile{path: path}
+}
+
+// Read returns the cursor recorded in the file, or "" if the file does not
+// exist yet.
+func (f *CursorFile) Read() (string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save atomically rewrites the file with cursor: it writes and fsyncs a
+// temporary file in the same directory, then renames it into place and
+// fsyncs the directory too, so a crash mid-write, or right after the
+// rename, never leaves a corrupt, truncated, or missing cursor behind.
+func (f *CursorFile) Save(cursor string) error {
+	dir := filepath.Dir(f.path)
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(cursor); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
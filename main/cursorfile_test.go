@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCursorFileSaveAndRead(t *testing.T) {
+	dir := t.TempDir()
+	f := NewCursorFile(filepath.Join(dir, "cursor"))
+
+	if got, err := f.Read(); err != nil || got != "" {
+		t.Fatalf("Read on missing file = %q, %v, want \"\", nil", got, err)
+	}
+
+	if err := f.Save("s=abc123"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := f.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got != "s=abc123" {
+		t.Errorf("Read = %q, want %q", got, "s=abc123")
+	}
+
+	if err := f.Save("s=def456"); err != nil {
+		t.Fatalf("Save (overwrite): %v", err)
+	}
+	if got, err := f.Read(); err != nil || got != "s=def456" {
+		t.Errorf("Read after overwrite = %q, %v, want %q, nil", got, err, "s=def456")
+	}
+}
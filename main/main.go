@@ -1,12 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/xytis/graylog-golang"
 	"github.com/xytis/j2g/journal"
+	"github.com/xytis/j2g/journal/export"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -49,6 +55,113 @@ func main() {
 		Usage: "gelf max chunk size for lan connection",
 	}
 
+	var flagPartialBufferSize = cli.IntFlag{
+		Name:  "partial-buffer-size",
+		Value: 1024,
+		Usage: "max number of containers with an in-flight CONTAINER_PARTIAL_MESSAGE to buffer at once",
+	}
+
+	var flagPartialBufferMaxMessageBytes = cli.IntFlag{
+		Name:  "partial-buffer-max-message-bytes",
+		Value: 1 << 20,
+		Usage: "max accumulated MESSAGE size for an in-flight CONTAINER_PARTIAL_MESSAGE before it is dropped",
+	}
+
+	var flagUnit = cli.StringSliceFlag{
+		Name:  "unit",
+		Usage: "only forward entries logged by this systemd unit, may be repeated",
+	}
+
+	var flagPriority = cli.StringFlag{
+		Name:  "priority",
+		Usage: "only forward entries at or above this syslog priority, e.g. 3 or 0..3",
+	}
+
+	var flagMatch = cli.StringSliceFlag{
+		Name:  "match",
+		Usage: "only forward entries matching KEY=VALUE, may be repeated",
+	}
+
+	var flagSince = cli.StringFlag{
+		Name:  "since",
+		Usage: "only forward entries logged at or after this RFC3339 timestamp",
+	}
+
+	var flagCursor = cli.StringFlag{
+		Name:  "cursor",
+		Usage: "resume forwarding after the entry at this journal cursor instead of the current time",
+	}
+
+	var flagCursorFile = cli.StringFlag{
+		Name:  "cursor-file",
+		Usage: "path to persist the journal cursor at, for resuming forwarding across restarts",
+	}
+
+	var flagCursorSyncEvery = cli.IntFlag{
+		Name:  "cursor-sync-every",
+		Value: 100,
+		Usage: "rewrite --cursor-file after this many entries have been sent",
+	}
+
+	var flagOutput = cli.StringFlag{
+		Name:  "output",
+		Value: "gelf",
+		Usage: "output format: gelf, export, or json",
+	}
+
+	var flagSink = cli.StringFlag{
+		Name:  "sink",
+		Value: "stdout",
+		Usage: "sink for export/json output: stdout, file, or tcp",
+	}
+
+	var flagSinkFile = cli.StringFlag{
+		Name:  "sink-file",
+		Usage: "file path to write to for --sink=file",
+	}
+
+	var flagSinkFileMaxSize = cli.IntFlag{
+		Name:  "sink-file-max-size",
+		Value: 100 * 1024 * 1024,
+		Usage: "rotate --sink-file once it reaches this many bytes",
+	}
+
+	var flagSinkFileMaxBackups = cli.IntFlag{
+		Name:  "sink-file-max-backups",
+		Value: 5,
+		Usage: "number of rotated --sink-file backups to keep",
+	}
+
+	var flagSinkTCPAddr = cli.StringFlag{
+		Name:  "sink-tcp-addr",
+		Usage: "host:port to write to for --sink=tcp, e.g. for systemd-journal-remote",
+	}
+
+	var flagDirectory = cli.StringFlag{
+		Name:  "directory",
+		Usage: "open the journal found in this directory instead of the local journal, e.g. a mounted /var/log/journal",
+	}
+
+	var flagFile = cli.StringSliceFlag{
+		Name:  "file",
+		Usage: "open this specific journal file instead of the local journal, may be repeated",
+	}
+
+	var flagNamespace = cli.StringFlag{
+		Name:  "namespace",
+		Usage: "open this journal namespace instead of the default one",
+	}
+
+	var flagSystem = cli.BoolFlag{
+		Name:  "system",
+		Usage: "only include system journal entries; combine with --directory, --file, or --namespace",
+	}
+
+	var flagUser = cli.BoolFlag{
+		Name:  "user",
+		Usage: "only include the current user's journal entries; combine with --directory, --file, or --namespace",
+	}
+
 	app := cli.NewApp()
 	app.Name = "j2g"
 	app.Usage = "journald forwarder to gelf endpoint"
@@ -60,16 +173,78 @@ func main() {
 		flagGelfConnection,
 		flagGelfMaxChunkSizeWan,
 		flagGelfMaxChunkSizeLan,
+		flagPartialBufferSize,
+		flagPartialBufferMaxMessageBytes,
+		flagUnit,
+		flagPriority,
+		flagMatch,
+		flagSince,
+		flagCursor,
+		flagCursorFile,
+		flagCursorSyncEvery,
+		flagOutput,
+		flagSink,
+		flagSinkFile,
+		flagSinkFileMaxSize,
+		flagSinkFileMaxBackups,
+		flagSinkTCPAddr,
+		flagDirectory,
+		flagFile,
+		flagNamespace,
+		flagSystem,
+		flagUser,
 	}
 
 	app.Action = Run
 	app.Run(os.Args)
 }
 
+// parsePriorityRange parses the --priority flag, either a single level
+// ("3", meaning 0..3, the journalctl convention) or an explicit "MIN..MAX"
+// range.
+func parsePriorityRange(s string) (int, int, error) {
+	if i := strings.Index(s, ".."); i >= 0 {
+		min, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid --priority %q: %v", s, err)
+		}
+		max, err := strconv.Atoi(s[i+2:])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid --priority %q: %v", s, err)
+		}
+		return min, max, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --priority %q: %v", s, err)
+	}
+	return 0, max, nil
+}
+
 func Run(ctx *cli.Context) {
 	SetLogLevel(ctx.String("log-level"))
 
-	j, err := journal.NewJournal()
+	openFlags := 0
+	if ctx.Bool("system") {
+		openFlags |= journal.SD_JOURNAL_SYSTEM
+	}
+	if ctx.Bool("user") {
+		openFlags |= journal.SD_JOURNAL_CURRENT_USER
+	}
+
+	var j *journal.Journal
+	var err error
+	switch {
+	case ctx.String("directory") != "":
+		j, err = journal.OpenDirectory(ctx.String("directory"), openFlags)
+	case len(ctx.StringSlice("file")) > 0:
+		j, err = journal.OpenFiles(ctx.StringSlice("file"), openFlags)
+	case ctx.String("namespace") != "":
+		j, err = journal.OpenNamespace(ctx.String("namespace"), openFlags|journal.SD_JOURNAL_LOCAL_ONLY)
+	default:
+		j, err = journal.Open(openFlags)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -82,59 +257,204 @@ func Run(ctx *cli.Context) {
 		MaxChunkSizeLan: ctx.Int("gelf-max-chunk-size-lan"),
 	})
 
-	start := time.Now()
-	if err := j.SeekRealtimeUsec(uint64(start.UnixNano() / 1000)); err != nil {
-		panic(err)
+	var cursorFile *CursorFile
+	if path := ctx.String("cursor-file"); path != "" {
+		cursorFile = NewCursorFile(path)
+	}
+
+	cursor := ctx.String("cursor")
+	if cursor == "" && cursorFile != nil {
+		saved, err := cursorFile.Read()
+		if err != nil {
+			panic(err)
+		}
+		cursor = saved
+	}
+
+	switch {
+	case cursor != "":
+		if err := j.SeekCursor(cursor); err != nil {
+			panic(err)
+		}
+		// SeekCursor only arms the seek; it takes a Next() to actually land
+		// on the cursor's entry before TestCursor can check it. That entry
+		// was already forwarded before the cursor was saved, so we leave
+		// the read pointer there: the listen loop's own Next() call advances
+		// past it to resume exactly where we left off.
+		stale := false
+		if c, err := j.Next(); err != nil {
+			panic(err)
+		} else if c == 0 {
+			stale = true
+		} else if ok, err := j.TestCursor(cursor); err != nil {
+			panic(err)
+		} else if !ok {
+			stale = true
+		}
+		if stale {
+			Log.Warnln("Recorded cursor no longer present in journal, falling back to tail")
+			if err := j.SeekTail(); err != nil {
+				panic(err)
+			}
+		}
+	default:
+		since := time.Now()
+		if s := ctx.String("since"); s != "" {
+			since, err = time.Parse(time.RFC3339, s)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if err := j.FilterSince(since); err != nil {
+			panic(err)
+		}
+	}
+
+	if units := ctx.StringSlice("unit"); len(units) > 0 {
+		if err := j.FilterUnits(units...); err != nil {
+			panic(err)
+		}
+	}
+
+	if p := ctx.String("priority"); p != "" {
+		min, max, err := parsePriorityRange(p)
+		if err != nil {
+			panic(err)
+		}
+		if err := j.FilterPriority(min, max); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, m := range ctx.StringSlice("match") {
+		parts := strings.SplitN(m, "=", 2)
+		if len(parts) != 2 {
+			panic(fmt.Errorf("invalid --match %q, expected KEY=VALUE", m))
+		}
+		if err := j.AddMatch(parts[0], parts[1]); err != nil {
+			panic(err)
+		}
+	}
+
+	output := ctx.String("output")
+
+	var sink Sink
+	if output != "gelf" {
+		switch ctx.String("sink") {
+		case "stdout", "":
+			sink = stdoutSink{}
+		case "file":
+			fs, err := newFileSink(ctx.String("sink-file"), int64(ctx.Int("sink-file-max-size")), ctx.Int("sink-file-max-backups"))
+			if err != nil {
+				panic(err)
+			}
+			defer fs.Close()
+			sink = fs
+		case "tcp":
+			ts, err := newTCPSink(ctx.String("sink-tcp-addr"))
+			if err != nil {
+				panic(err)
+			}
+			defer ts.Close()
+			sink = ts
+		default:
+			panic(fmt.Errorf("unknown --sink %q", ctx.String("sink")))
+		}
 	}
 
+	partial := journal.NewPartialBuffer(ctx.Int("partial-buffer-size"), ctx.Int("partial-buffer-max-message-bytes"))
+	cursorSyncEvery := ctx.Int("cursor-sync-every")
+	sent := 0
+
 	Log.Infoln("Starting journal")
-	until := make(chan struct{})
-	event := make(chan int, 1)
-	done := make(chan struct{}, 1)
+	notifyCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	signal.Notify(c, syscall.SIGTERM)
 	go func() {
 		<-c
-		until <- struct{}{}
+		cancel()
 	}()
+
+	events := j.Notify(notifyCtx)
 listen:
 	for {
-		c, err := j.Next()
-		if err != nil {
-			Log.Errorf("Journal traversing error %v\n:", err)
-			continue
-		}
-		if c == 1 {
+	drain:
+		for {
+			c, err := j.Next()
+			if err != nil {
+				Log.Errorf("Journal traversing error %v\n:", err)
+				continue drain
+			}
+			if c == 0 {
+				break drain
+			}
+
 			e, err := j.GetEntry()
 			if err != nil {
 				Log.Errorf("Skiping unreadable entry: %v\n", err)
-				continue
+				continue drain
+			}
+			if usec, err := j.GetRealtimeUsec(); err != nil {
+				Log.Errorf("Failed to get entry timestamp: %v\n", err)
+			} else {
+				e["__REALTIME_TIMESTAMP"] = strconv.FormatUint(usec, 10)
 			}
 			Log.Debugf("Received entry: %v\n", e)
-			raw, err := json.Marshal(e)
+			full, ready := partial.Add(e)
+			if !ready {
+				continue drain
+			}
+
+			var raw []byte
+			switch output {
+			case "gelf":
+				raw, err = journal.ToGELF(full)
+			case "export":
+				var buf bytes.Buffer
+				err = export.WriteEntry(&buf, full)
+				raw = buf.Bytes()
+			case "json":
+				raw, err = json.Marshal(full)
+				if err == nil {
+					raw = append(raw, '\n')
+				}
+			default:
+				err = fmt.Errorf("unknown --output %q", output)
+			}
 			if err != nil {
-				Log.Errorf("Skipping unserializable entry: %v\n", e)
-				continue
-			}
-			g.RawLog(raw)
-		}
-		go func() {
-			for {
-				select {
-				case <-done:
-					return
-				default:
-					event <- j.Wait(time.Duration(1) * time.Second)
+				Log.Errorf("Skipping unconvertible entry: %v\n", err)
+				continue drain
+			}
+			if raw == nil {
+				continue drain
+			}
+
+			if output == "gelf" {
+				g.RawLog(raw)
+			} else if err := sink.Write(raw); err != nil {
+				Log.Errorf("Failed to write entry to sink: %v\n", err)
+			}
+
+			sent++
+			if cursorFile != nil && cursorSyncEvery > 0 && sent%cursorSyncEvery == 0 {
+				if cursor, err := j.GetCursor(); err != nil {
+					Log.Errorf("Failed to get cursor: %v\n", err)
+				} else if err := cursorFile.Save(cursor); err != nil {
+					Log.Errorf("Failed to persist cursor: %v\n", err)
 				}
 			}
-		}()
+		}
+
 		select {
-		case <-until:
-			done <- struct{}{}
+		case <-notifyCtx.Done():
 			break listen
-		case e := <-event:
-			done <- struct{}{}
+		case e, ok := <-events:
+			if !ok {
+				break listen
+			}
 			switch e {
 			case journal.SD_JOURNAL_NOP, journal.SD_JOURNAL_APPEND, journal.SD_JOURNAL_INVALIDATE:
 				// TODO: need to account for any of these?
@@ -145,6 +465,14 @@ listen:
 			continue
 		}
 	}
+	if cursorFile != nil {
+		if cursor, err := j.GetCursor(); err != nil {
+			Log.Errorf("Failed to get cursor: %v\n", err)
+		} else if err := cursorFile.Save(cursor); err != nil {
+			Log.Errorf("Failed to persist cursor: %v\n", err)
+		}
+	}
+
 	Log.Infoln("Closing journal")
 	j.Close()
 }
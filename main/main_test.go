@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestParsePriorityRange(t *testing.T) {
+	cases := []struct {
+		in      string
+		min     int
+		max     int
+		wantErr bool
+	}{
+		{in: "3", min: 0, max: 3},
+		{in: "0..3", min: 0, max: 3},
+		{in: "2..5", min: 2, max: 5},
+		{in: "", wantErr: true},
+		{in: "x", wantErr: true},
+		{in: "1..x", wantErr: true},
+		{in: "x..1", wantErr: true},
+	}
+
+	for _, c := range cases {
+		min, max, err := parsePriorityRange(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parsePriorityRange(%q): expected error, got min=%d max=%d", c.in, min, max)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePriorityRange(%q): unexpected error: %v", c.in, err)
+			continue
+		}
+		if min != c.min || max != c.max {
+			t.Errorf("parsePriorityRange(%q) = %d, %d, want %d, %d", c.in, min, max, c.min, c.max)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// Sink is a destination for serialized log records, used by the export and
+// json output formats. GELF output keeps using the graylog-golang client
+// directly, since that already implements GELF's UDP chunking.
+type Sink interface {
+	Write(raw []byte) error
+	Close() error
+}
+
+// stdoutSink writes records to standard output.
+type stdoutSink struct{}
+
+func (stdoutSink) Write(raw []byte) error {
+	_, err := os.Stdout.Write(raw)
+	return err
+}
+
+func (stdoutSink) Close() error { return nil }
+
+// fileSink writes records to a file, rotating it once it reaches maxBytes.
+// Rotated files are renamed path.1, path.2, ... up to maxBackups, the
+// oldest being discarded.
+type fileSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newFileSink(path string, maxBytes int64, maxBackups int) (*fileSink, error) {
+	s := &fileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) Write(raw []byte) error {
+	if s.maxBytes > 0 && s.size+int64(len(raw)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(raw)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups <= 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return s.open()
+	}
+	os.Remove(s.backupPath(s.maxBackups))
+	for i := s.maxBackups - 1; i > 0; i-- {
+		os.Rename(s.backupPath(i), s.backupPath(i+1))
+	}
+	if err := os.Rename(s.path, s.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// tcpSink writes records to a TCP endpoint, suitable for feeding
+// systemd-journal-remote.
+type tcpSink struct {
+	conn net.Conn
+}
+
+func newTCPSink(addr string) (*tcpSink, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpSink{conn: conn}, nil
+}
+
+func (s *tcpSink) Write(raw []byte) error {
+	_, err := s.conn.Write(raw)
+	return err
+}
+
+func (s *tcpSink) Close() error {
+	return s.conn.Close()
+}
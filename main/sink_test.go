@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSinkRotateKeepsExactlyMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sink.log")
+
+	s, err := newFileSink(path, 1, 2)
+	if err != nil {
+		t.Fatalf("newFileSink: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := s.Write([]byte(fmt.Sprintf("entry %d\n", i))); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	for _, n := range []int{1, 2} {
+		if _, err := os.Stat(s.backupPath(n)); err != nil {
+			t.Errorf("expected backup %d to exist: %v", n, err)
+		}
+	}
+	if _, err := os.Stat(s.backupPath(3)); !os.IsNotExist(err) {
+		t.Errorf("expected backup 3 to not exist, got err=%v", err)
+	}
+}
+
+func TestFileSinkRotateWithNoBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sink.log")
+
+	s, err := newFileSink(path, 1, 0)
+	if err != nil {
+		t.Fatalf("newFileSink: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := s.Write([]byte(fmt.Sprintf("entry %d\n", i))); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(s.backupPath(1)); !os.IsNotExist(err) {
+		t.Errorf("expected no backups to exist, got err=%v", err)
+	}
+}